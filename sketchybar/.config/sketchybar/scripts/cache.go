@@ -0,0 +1,115 @@
+package main
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"sort"
+	"time"
+)
+
+// defaultCacheSize is the number of cached palette results kept on disk
+// before the oldest (by last access) are evicted.
+const defaultCacheSize = 128
+
+func cacheDir() (string, error) {
+	home, err := os.UserHomeDir()
+	if err != nil {
+		return "", err
+	}
+	dir := filepath.Join(home, ".cache", "sketchybar-artwork")
+	if err := os.MkdirAll(dir, 0o755); err != nil {
+		return "", err
+	}
+	return dir, nil
+}
+
+func cacheKey(artworkURL string) string {
+	sum := sha256.Sum256([]byte(artworkURL))
+	return hex.EncodeToString(sum[:])
+}
+
+// loadCachedResult returns the previously computed colors for artworkURL, if
+// present. A hit touches the file's mtime so the LRU eviction in
+// storeCachedResult sees it as recently used.
+func loadCachedResult(artworkURL string) (colorResult, bool) {
+	dir, err := cacheDir()
+	if err != nil {
+		return colorResult{}, false
+	}
+	path := filepath.Join(dir, cacheKey(artworkURL)+".json")
+
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return colorResult{}, false
+	}
+
+	var r colorResult
+	if err := json.Unmarshal(data, &r); err != nil {
+		return colorResult{}, false
+	}
+
+	now := time.Now()
+	_ = os.Chtimes(path, now, now)
+
+	return r, true
+}
+
+// storeCachedResult persists r for artworkURL, then evicts the least
+// recently used entries down to maxEntries.
+func storeCachedResult(artworkURL string, r colorResult, maxEntries int) {
+	dir, err := cacheDir()
+	if err != nil {
+		return
+	}
+	path := filepath.Join(dir, cacheKey(artworkURL)+".json")
+
+	data, err := json.Marshal(r)
+	if err != nil {
+		return
+	}
+	if err := os.WriteFile(path, data, 0o644); err != nil {
+		return
+	}
+
+	evictLRU(dir, maxEntries)
+}
+
+func evictLRU(dir string, maxEntries int) {
+	if maxEntries < 0 {
+		maxEntries = 0
+	}
+
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		return
+	}
+
+	type aged struct {
+		path    string
+		modTime time.Time
+	}
+	var files []aged
+	for _, e := range entries {
+		if e.IsDir() {
+			continue
+		}
+		info, err := e.Info()
+		if err != nil {
+			continue
+		}
+		files = append(files, aged{filepath.Join(dir, e.Name()), info.ModTime()})
+	}
+
+	if len(files) <= maxEntries {
+		return
+	}
+
+	sort.Slice(files, func(i, j int) bool { return files[i].modTime.Before(files[j].modTime) })
+	evictCount := min(len(files)-maxEntries, len(files))
+	for _, f := range files[:evictCount] {
+		_ = os.Remove(f.path)
+	}
+}
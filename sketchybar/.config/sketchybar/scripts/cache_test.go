@@ -0,0 +1,86 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func TestCacheKey(t *testing.T) {
+	a := cacheKey("https://example.com/art.jpg")
+	b := cacheKey("https://example.com/art.jpg")
+	c := cacheKey("https://example.com/other.jpg")
+
+	if a != b {
+		t.Errorf("cacheKey is not deterministic: %q != %q", a, b)
+	}
+	if a == c {
+		t.Errorf("cacheKey collided for different URLs: %q", a)
+	}
+}
+
+// writeAgedFiles creates n files in dir, each stamped with a distinct mtime
+// increasing with index (file 0 is oldest), for exercising evictLRU.
+func writeAgedFiles(t *testing.T, dir string, n int) {
+	t.Helper()
+	base := time.Now().Add(-time.Hour)
+	for i := 0; i < n; i++ {
+		p := filepath.Join(dir, fmt.Sprintf("entry-%d.json", i))
+		if err := os.WriteFile(p, []byte("{}"), 0o644); err != nil {
+			t.Fatal(err)
+		}
+		mt := base.Add(time.Duration(i) * time.Minute)
+		if err := os.Chtimes(p, mt, mt); err != nil {
+			t.Fatal(err)
+		}
+	}
+}
+
+func TestEvictLRU(t *testing.T) {
+	tests := []struct {
+		name          string
+		numFiles      int
+		maxEntries    int
+		wantRemaining int
+	}{
+		{"under limit keeps all", 3, 5, 3},
+		{"at limit keeps all", 3, 3, 3},
+		{"over limit evicts oldest", 5, 2, 2},
+		{"zero evicts everything", 3, 0, 0},
+		{"negative maxEntries clamps to zero instead of panicking", 3, -1, 0},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			dir := t.TempDir()
+			writeAgedFiles(t, dir, tt.numFiles)
+
+			evictLRU(dir, tt.maxEntries)
+
+			entries, err := os.ReadDir(dir)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if len(entries) != tt.wantRemaining {
+				t.Errorf("evictLRU(dir, %d) left %d files, want %d", tt.maxEntries, len(entries), tt.wantRemaining)
+			}
+		})
+	}
+}
+
+func TestEvictLRUKeepsMostRecentlyUsed(t *testing.T) {
+	dir := t.TempDir()
+	writeAgedFiles(t, dir, 3) // entry-0 oldest, entry-2 newest
+
+	evictLRU(dir, 1)
+
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(entries) != 1 || entries[0].Name() != "entry-2.json" {
+		t.Errorf("evictLRU(dir, 1) kept %v, want only entry-2.json", entries)
+	}
+}
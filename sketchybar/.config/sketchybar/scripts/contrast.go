@@ -0,0 +1,81 @@
+package main
+
+import (
+	"image/color"
+	"math"
+)
+
+// minContrastRatio is the WCAG 2.1 AA threshold for normal-size text.
+const minContrastRatio = 4.5
+
+var (
+	wcagBlack = color.RGBA{A: 255}
+	wcagWhite = color.RGBA{R: 255, G: 255, B: 255, A: 255}
+)
+
+// luminance computes WCAG 2.1 relative luminance from sRGB channels.
+func luminance(c color.RGBA) float64 {
+	linearize := func(v uint8) float64 {
+		f := float64(v) / 255
+		if f <= 0.03928 {
+			return f / 12.92
+		}
+		return math.Pow((f+0.055)/1.055, 2.4)
+	}
+	return 0.2126*linearize(c.R) + 0.7152*linearize(c.G) + 0.0722*linearize(c.B)
+}
+
+// contrastRatio is the WCAG contrast ratio between two relative luminances.
+func contrastRatio(l1, l2 float64) float64 {
+	lighter, darker := l1, l2
+	if darker > lighter {
+		lighter, darker = darker, lighter
+	}
+	return (lighter + 0.05) / (darker + 0.05)
+}
+
+func adjustToward(c color.RGBA, target color.RGBA, step float64) color.RGBA {
+	lerp := func(from, to uint8) uint8 {
+		return uint8(float64(from) + (float64(to)-float64(from))*step)
+	}
+	return color.RGBA{R: lerp(c.R, target.R), G: lerp(c.G, target.G), B: lerp(c.B, target.B), A: 255}
+}
+
+// readableTextColor picks a foreground (black or white) that meets the WCAG
+// AA contrast ratio (4.5:1) against bg. If neither candidate clears the bar
+// on the first try, bg is nudged toward black or white in small steps until
+// one does; the possibly-adjusted background is returned alongside it so
+// callers can emit a consistent BACKGROUND/LABEL/ICON triple.
+func readableTextColor(bg color.RGBA) (fg, adjustedBg color.RGBA) {
+	const maxSteps = 20
+	const stepSize = 0.05
+
+	for i := 0; i <= maxSteps; i++ {
+		lbg := luminance(bg)
+		contrastBlack := contrastRatio(luminance(wcagBlack), lbg)
+		contrastWhite := contrastRatio(lbg, luminance(wcagWhite))
+
+		switch {
+		case contrastBlack >= minContrastRatio && contrastBlack >= contrastWhite:
+			return wcagBlack, bg
+		case contrastWhite >= minContrastRatio:
+			return wcagWhite, bg
+		}
+
+		// Neither passes yet: push the background toward whichever pole is
+		// already closer, so it converges in one direction instead of
+		// oscillating.
+		if contrastBlack >= contrastWhite {
+			bg = adjustToward(bg, color.RGBA{R: 255, G: 255, B: 255, A: 255}, stepSize)
+		} else {
+			bg = adjustToward(bg, color.RGBA{A: 255}, stepSize)
+		}
+	}
+
+	// Fell through without clearing 4.5:1 (pathological input); return the
+	// best candidate we found at the last step.
+	if contrastRatio(luminance(wcagBlack), luminance(bg)) >= contrastRatio(luminance(bg), luminance(wcagWhite)) {
+		return wcagBlack, bg
+	}
+	return wcagWhite, bg
+}
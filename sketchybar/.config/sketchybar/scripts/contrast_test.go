@@ -0,0 +1,76 @@
+package main
+
+import (
+	"image/color"
+	"math"
+	"testing"
+)
+
+func TestLuminance(t *testing.T) {
+	tests := []struct {
+		name string
+		c    color.RGBA
+		want float64
+	}{
+		{"black", color.RGBA{R: 0, G: 0, B: 0, A: 255}, 0},
+		{"white", color.RGBA{R: 255, G: 255, B: 255, A: 255}, 1},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := luminance(tt.c)
+			if math.Abs(got-tt.want) > 1e-9 {
+				t.Errorf("luminance(%v) = %v, want %v", tt.c, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestContrastRatio(t *testing.T) {
+	tests := []struct {
+		name   string
+		l1, l2 float64
+		want   float64
+	}{
+		{"black vs white", 0, 1, 21},
+		{"order is irrelevant", 1, 0, 21},
+		{"identical luminance", 0.5, 0.5, 1},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := contrastRatio(tt.l1, tt.l2)
+			if math.Abs(got-tt.want) > 1e-9 {
+				t.Errorf("contrastRatio(%v, %v) = %v, want %v", tt.l1, tt.l2, got, tt.want)
+			}
+		})
+	}
+}
+
+// TestReadableTextColorMeetsAA checks that for a range of backgrounds --
+// including mid-grays near the point where black and white text contrast
+// about equally -- the returned foreground/background pair always clears
+// the WCAG AA threshold.
+func TestReadableTextColorMeetsAA(t *testing.T) {
+	tests := []struct {
+		name string
+		bg   color.RGBA
+	}{
+		{"pure white", color.RGBA{R: 255, G: 255, B: 255, A: 255}},
+		{"pure black", color.RGBA{R: 0, G: 0, B: 0, A: 255}},
+		{"mid gray", color.RGBA{R: 128, G: 128, B: 128, A: 255}},
+		{"pathological near-threshold gray", color.RGBA{R: 119, G: 119, B: 119, A: 255}},
+		{"saturated mid-tone", color.RGBA{R: 200, G: 60, B: 60, A: 255}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			fg, adjustedBg := readableTextColor(tt.bg)
+			ratio := contrastRatio(luminance(fg), luminance(adjustedBg))
+			if ratio < minContrastRatio-1e-9 {
+				t.Errorf("readableTextColor(%v) = fg %v, bg %v; contrast ratio %v is below %v",
+					tt.bg, fg, adjustedBg, ratio, minContrastRatio)
+			}
+		})
+	}
+}
@@ -0,0 +1,190 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"image/color"
+	"strings"
+)
+
+type outputFormat string
+
+const (
+	formatSketchybar outputFormat = "sketchybar"
+	formatWaybar     outputFormat = "waybar"
+	formatJSON       outputFormat = "json"
+	formatShell      outputFormat = "shell"
+)
+
+type colorField string
+
+const (
+	fieldBackground colorField = "background"
+	fieldLabel      colorField = "label"
+	fieldIcon       colorField = "icon"
+	fieldDominant   colorField = "dominant"
+	fieldAccent     colorField = "accent"
+	fieldMuted      colorField = "muted"
+)
+
+var allColorFields = []colorField{fieldBackground, fieldLabel, fieldIcon, fieldDominant, fieldAccent, fieldMuted}
+
+func defaultEmitFlag() string {
+	names := make([]string, len(allColorFields))
+	for i, f := range allColorFields {
+		names[i] = string(f)
+	}
+	return strings.Join(names, ",")
+}
+
+// parseEmitFields turns a comma-separated --emit value into a lookup set.
+// Unknown names are ignored so a typo just omits that field rather than
+// failing the whole invocation (this runs on every SketchyBar tick).
+func parseEmitFields(s string) map[colorField]bool {
+	set := make(map[colorField]bool)
+	for _, part := range strings.Split(s, ",") {
+		part = strings.TrimSpace(part)
+		if part == "" {
+			continue
+		}
+		set[colorField(part)] = true
+	}
+	return set
+}
+
+func hexString(c color.RGBA) string {
+	return fmt.Sprintf("#%02X%02X%02X", c.R, c.G, c.B)
+}
+
+func (r colorResult) has(f colorField) bool {
+	switch f {
+	case fieldAccent:
+		return r.HasAccent
+	case fieldMuted:
+		return r.HasMuted
+	default:
+		return true
+	}
+}
+
+func (r colorResult) get(f colorField) color.RGBA {
+	switch f {
+	case fieldBackground:
+		return r.Background
+	case fieldLabel:
+		return r.Label
+	case fieldIcon:
+		return r.Icon
+	case fieldDominant:
+		return r.Dominant
+	case fieldAccent:
+		return r.Accent
+	case fieldMuted:
+		return r.Muted
+	default:
+		return color.RGBA{}
+	}
+}
+
+func renderSketchybar(r colorResult, emit map[colorField]bool) string {
+	var sb strings.Builder
+	for _, f := range allColorFields {
+		if !emit[f] || !r.has(f) {
+			continue
+		}
+		c := r.get(f)
+		fmt.Fprintf(&sb, "%s=0xFF%02X%02X%02X\n", strings.ToUpper(string(f)), c.R, c.G, c.B)
+	}
+	return sb.String()
+}
+
+func renderShell(r colorResult, emit map[colorField]bool) string {
+	var sb strings.Builder
+	for _, f := range allColorFields {
+		if !emit[f] || !r.has(f) {
+			continue
+		}
+		c := r.get(f)
+		fmt.Fprintf(&sb, "export %s=0xFF%02X%02X%02X\n", strings.ToUpper(string(f)), c.R, c.G, c.B)
+	}
+	return sb.String()
+}
+
+type jsonColor struct {
+	Hex string `json:"hex"`
+	R   uint8  `json:"r"`
+	G   uint8  `json:"g"`
+	B   uint8  `json:"b"`
+}
+
+type jsonOutput struct {
+	Track   Track                    `json:"track"`
+	Palette map[colorField]jsonColor `json:"palette"`
+}
+
+func renderJSON(r colorResult, track Track, emit map[colorField]bool) (string, error) {
+	out := jsonOutput{Track: track, Palette: make(map[colorField]jsonColor)}
+	for _, f := range allColorFields {
+		if !emit[f] || !r.has(f) {
+			continue
+		}
+		c := r.get(f)
+		out.Palette[f] = jsonColor{Hex: hexString(c), R: c.R, G: c.G, B: c.B}
+	}
+
+	data, err := json.Marshal(out)
+	if err != nil {
+		return "", err
+	}
+	return string(data) + "\n", nil
+}
+
+type waybarStyle struct {
+	Background string `json:"background,omitempty"`
+	Color      string `json:"color,omitempty"`
+}
+
+type waybarOutput struct {
+	Text    string      `json:"text"`
+	Tooltip string      `json:"tooltip"`
+	Class   string      `json:"class"`
+	Style   waybarStyle `json:"style"`
+}
+
+func renderWaybar(r colorResult, track Track, emit map[colorField]bool) (string, error) {
+	text := fmt.Sprintf("%s — %s", track.Artist, track.Title)
+	out := waybarOutput{
+		Text:    text,
+		Tooltip: text,
+		Class:   "now-playing",
+	}
+	if emit[fieldBackground] {
+		out.Style.Background = hexString(r.Background)
+	}
+	if emit[fieldLabel] {
+		out.Style.Color = hexString(r.Label)
+	}
+
+	data, err := json.Marshal(out)
+	if err != nil {
+		return "", err
+	}
+	return string(data) + "\n", nil
+}
+
+// renderResult renders r (plus track metadata, needed by the waybar and json
+// modes) in the requested format, honoring which fields --emit selected.
+func renderResult(format outputFormat, r colorResult, track Track, emit map[colorField]bool) (string, error) {
+	switch format {
+	case formatSketchybar:
+		return renderSketchybar(r, emit), nil
+	case formatShell:
+		return renderShell(r, emit), nil
+	case formatJSON:
+		return renderJSON(r, track, emit)
+	case formatWaybar:
+		return renderWaybar(r, track, emit)
+	default:
+		return "", fmt.Errorf("unknown output format %q", format)
+	}
+}
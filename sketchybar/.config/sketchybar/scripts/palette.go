@@ -0,0 +1,250 @@
+package main
+
+import (
+	"image/color"
+	"math"
+	"math/rand"
+)
+
+/* ---------- sRGB <-> CIELAB (D65) ---------- */
+
+type labColor struct {
+	L, A, B float64
+}
+
+func srgbToLinear(v float64) float64 {
+	v /= 255
+	if v <= 0.04045 {
+		return v / 12.92
+	}
+	return math.Pow((v+0.055)/1.055, 2.4)
+}
+
+// D65 reference white, sRGB primaries.
+const (
+	whiteX = 95.0489
+	whiteY = 100.0
+	whiteZ = 108.8840
+)
+
+func rgbToXYZ(c color.RGBA) (x, y, z float64) {
+	r := srgbToLinear(float64(c.R))
+	g := srgbToLinear(float64(c.G))
+	b := srgbToLinear(float64(c.B))
+
+	x = (r*0.4124564 + g*0.3575761 + b*0.1804375) * 100
+	y = (r*0.2126729 + g*0.7151522 + b*0.0721750) * 100
+	z = (r*0.0193339 + g*0.1191920 + b*0.9503041) * 100
+	return
+}
+
+func labF(t float64) float64 {
+	const delta = 6.0 / 29.0
+	if t > delta*delta*delta {
+		return math.Cbrt(t)
+	}
+	return t/(3*delta*delta) + 4.0/29.0
+}
+
+func rgbToLab(c color.RGBA) labColor {
+	x, y, z := rgbToXYZ(c)
+	fx := labF(x / whiteX)
+	fy := labF(y / whiteY)
+	fz := labF(z / whiteZ)
+
+	return labColor{
+		L: 116*fy - 16,
+		A: 500 * (fx - fy),
+		B: 200 * (fy - fz),
+	}
+}
+
+func labDistSq(a, b labColor) float64 {
+	dl := a.L - b.L
+	da := a.A - b.A
+	db := a.B - b.B
+	return dl*dl + da*da + db*db
+}
+
+func (c labColor) chroma() float64 {
+	return math.Sqrt(c.A*c.A + c.B*c.B)
+}
+
+/* ---------- k-means in Lab space ---------- */
+
+const (
+	kmeansMaxIters  = 20
+	nearBlackL      = 8.0
+	nearWhiteL      = 95.0
+	minUsableChroma = 6.0
+)
+
+// kmeansPlusPlusSeed picks k initial centers, weighting candidates by their
+// squared distance to the nearest already-chosen center.
+func kmeansPlusPlusSeed(points []labColor, k int) []labColor {
+	centers := make([]labColor, 0, k)
+	centers = append(centers, points[rand.Intn(len(points))])
+
+	for len(centers) < k {
+		distSq := make([]float64, len(points))
+		var total float64
+		for i, p := range points {
+			best := math.MaxFloat64
+			for _, c := range centers {
+				if d := labDistSq(p, c); d < best {
+					best = d
+				}
+			}
+			distSq[i] = best
+			total += best
+		}
+
+		if total == 0 {
+			centers = append(centers, points[rand.Intn(len(points))])
+			continue
+		}
+
+		target := rand.Float64() * total
+		var cum float64
+		for i, d := range distSq {
+			cum += d
+			if cum >= target {
+				centers = append(centers, points[i])
+				break
+			}
+		}
+	}
+
+	return centers
+}
+
+func kmeans(points []labColor, k int) (centers []labColor, assignments []int) {
+	if len(points) < k {
+		k = len(points)
+	}
+	centers = kmeansPlusPlusSeed(points, k)
+	assignments = make([]int, len(points))
+
+	for iter := 0; iter < kmeansMaxIters; iter++ {
+		changed := false
+		for i, p := range points {
+			best, bestIdx := math.MaxFloat64, 0
+			for c, center := range centers {
+				if d := labDistSq(p, center); d < best {
+					best, bestIdx = d, c
+				}
+			}
+			if assignments[i] != bestIdx {
+				changed = true
+				assignments[i] = bestIdx
+			}
+		}
+
+		sums := make([]labColor, k)
+		counts := make([]int, k)
+		for i, p := range points {
+			a := assignments[i]
+			sums[a].L += p.L
+			sums[a].A += p.A
+			sums[a].B += p.B
+			counts[a]++
+		}
+		for c := range centers {
+			if counts[c] == 0 {
+				continue
+			}
+			centers[c] = labColor{
+				L: sums[c].L / float64(counts[c]),
+				A: sums[c].A / float64(counts[c]),
+				B: sums[c].B / float64(counts[c]),
+			}
+		}
+
+		if !changed {
+			break
+		}
+	}
+
+	return centers, assignments
+}
+
+type paletteEntry struct {
+	rgb    color.RGBA
+	weight int
+}
+
+// extractPalette clusters pixels into k groups in CIELAB space and returns
+// them ranked by weighted population, largest first. Clusters that are
+// near-black, near-white, or too low-chroma to read as a real color are
+// dropped; if every cluster gets filtered out (e.g. a grayscale cover) the
+// unfiltered ranking is returned instead. pixels is expected to come from
+// sampleImage.
+func extractPalette(pixels []color.RGBA, k int) []color.RGBA {
+	if len(pixels) == 0 {
+		return nil
+	}
+	if k < 1 {
+		k = 1
+	}
+
+	labs := make([]labColor, len(pixels))
+	for i, p := range pixels {
+		labs[i] = rgbToLab(p)
+	}
+
+	centers, assignments := kmeans(labs, k)
+
+	type accum struct {
+		rSum, gSum, bSum, count uint64
+	}
+	buckets := make([]accum, len(centers))
+	for i, p := range pixels {
+		c := assignments[i]
+		buckets[c].rSum += uint64(p.R)
+		buckets[c].gSum += uint64(p.G)
+		buckets[c].bSum += uint64(p.B)
+		buckets[c].count++
+	}
+
+	rank := func(filtered bool) []paletteEntry {
+		var entries []paletteEntry
+		for _, b := range buckets {
+			if b.count == 0 {
+				continue
+			}
+			avg := color.RGBA{
+				R: uint8(b.rSum / b.count),
+				G: uint8(b.gSum / b.count),
+				B: uint8(b.bSum / b.count),
+				A: 255,
+			}
+			if filtered {
+				lab := rgbToLab(avg)
+				if lab.L < nearBlackL || lab.L > nearWhiteL || lab.chroma() < minUsableChroma {
+					continue
+				}
+			}
+			entries = append(entries, paletteEntry{rgb: avg, weight: int(b.count)})
+		}
+		return entries
+	}
+
+	entries := rank(true)
+	if len(entries) == 0 {
+		entries = rank(false)
+	}
+
+	for i := 0; i < len(entries); i++ {
+		for j := i + 1; j < len(entries); j++ {
+			if entries[j].weight > entries[i].weight {
+				entries[i], entries[j] = entries[j], entries[i]
+			}
+		}
+	}
+
+	palette := make([]color.RGBA, len(entries))
+	for i, e := range entries {
+		palette[i] = e.rgb
+	}
+	return palette
+}
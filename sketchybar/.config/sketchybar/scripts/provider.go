@@ -0,0 +1,63 @@
+package main
+
+import "fmt"
+
+// Track is the now-playing state reported by a Provider, independent of
+// which media player or desktop it came from.
+type Track struct {
+	Title      string `json:"title"`
+	Artist     string `json:"artist"`
+	ArtworkURL string `json:"artwork_url"` // http(s):// or file:// depending on the provider
+	Playing    bool   `json:"playing"`
+}
+
+// Provider knows how to ask one media player what's currently playing.
+// candidateProviders() (defined per-platform, see providers_darwin.go and
+// providers_linux.go) lists the providers available on the current OS.
+type Provider interface {
+	// Name identifies the provider for the --provider flag and log output.
+	Name() string
+	// NowPlaying returns the current track. A nil error with Playing false
+	// means the provider is reachable but nothing is currently playing.
+	NowPlaying() (Track, error)
+}
+
+// selectProvider resolves the --provider flag to a concrete Provider and
+// returns the Track it already fetched while probing, so callers don't need
+// a second NowPlaying() round trip just to get the track they just proved
+// was playing (that round trip is a real AppleScript invocation for the
+// Apple Music provider, not a free call).
+//
+// "auto" probes candidateProviders() in order and returns the first one
+// that reports something actually playing; any other value must match a
+// Provider.Name() exactly.
+func selectProvider(name string) (Provider, Track, error) {
+	candidates := candidateProviders()
+	if len(candidates) == 0 {
+		return nil, Track{}, fmt.Errorf("no now-playing providers available on this platform")
+	}
+
+	if name != "auto" {
+		for _, p := range candidates {
+			if p.Name() == name {
+				track, err := p.NowPlaying()
+				return p, track, err
+			}
+		}
+		return nil, Track{}, fmt.Errorf("unknown or unsupported provider %q on this platform", name)
+	}
+
+	var fallbackProvider Provider
+	var fallbackTrack Track
+	var fallbackErr error
+	for i, p := range candidates {
+		track, err := p.NowPlaying()
+		if i == 0 {
+			fallbackProvider, fallbackTrack, fallbackErr = p, track, err
+		}
+		if err == nil && track.Playing {
+			return p, track, nil
+		}
+	}
+	return fallbackProvider, fallbackTrack, fallbackErr
+}
@@ -0,0 +1,98 @@
+//go:build darwin
+
+package main
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+)
+
+func runAppleScript(script string) (string, error) {
+	cmd := exec.Command("osascript", "-e", script)
+	var out bytes.Buffer
+	cmd.Stdout = &out
+	err := cmd.Run()
+	return strings.TrimSpace(out.String()), err
+}
+
+func candidateProviders() []Provider {
+	return []Provider{spotifyProvider{}, appleMusicProvider{}}
+}
+
+/* ---------- Spotify ---------- */
+
+type spotifyProvider struct{}
+
+func (spotifyProvider) Name() string { return "spotify" }
+
+func (spotifyProvider) NowPlaying() (Track, error) {
+	out, err := runAppleScript(`tell application "Spotify"
+		if it is running and player state is playing then
+			return (name of current track) & "|||" & (artist of current track) & "|||" & (artwork url of current track)
+		end if
+	end tell`)
+	if err != nil {
+		return Track{}, err
+	}
+	if out == "" {
+		return Track{}, nil
+	}
+
+	parts := strings.SplitN(out, "|||", 3)
+	if len(parts) != 3 {
+		return Track{}, fmt.Errorf("spotify: unexpected AppleScript output %q", out)
+	}
+	return Track{Title: parts[0], Artist: parts[1], ArtworkURL: parts[2], Playing: true}, nil
+}
+
+/* ---------- Apple Music ---------- */
+
+type appleMusicProvider struct{}
+
+func (appleMusicProvider) Name() string { return "music" }
+
+// Music.app doesn't expose an artwork URL over AppleScript like Spotify
+// does; the artwork is raw bytes on the current track. We export it to a
+// temp path named from a hash of the track's title+artist and hand back a
+// file:// URL so callers (including the URL-keyed artwork cache) can treat
+// both providers the same way. A fixed path here would collide across
+// tracks and make every song reuse the first one's cached colors.
+func (appleMusicProvider) NowPlaying() (Track, error) {
+	out, err := runAppleScript(`tell application "Music"
+		if it is running and player state is playing then
+			return (name of current track) & "|||" & (artist of current track)
+		end if
+	end tell`)
+	if err != nil {
+		return Track{}, err
+	}
+	if out == "" {
+		return Track{}, nil
+	}
+
+	parts := strings.SplitN(out, "|||", 2)
+	if len(parts) != 2 {
+		return Track{}, fmt.Errorf("music: unexpected AppleScript output %q", out)
+	}
+
+	sum := sha256.Sum256([]byte(parts[0] + "|||" + parts[1]))
+	artPath := filepath.Join(os.TempDir(), "sketchybar-music-artwork-"+hex.EncodeToString(sum[:])+".jpg")
+	_, err = runAppleScript(fmt.Sprintf(`tell application "Music"
+		set artData to data of artwork 1 of current track
+		set fp to open for access (POSIX file %q) with write permission
+		set eof of fp to 0
+		write artData to fp
+		close access fp
+	end tell`, artPath))
+	if err != nil {
+		return Track{}, fmt.Errorf("music: exporting artwork: %w", err)
+	}
+
+	return Track{Title: parts[0], Artist: parts[1], ArtworkURL: "file://" + artPath, Playing: true}, nil
+}
@@ -0,0 +1,51 @@
+//go:build linux
+
+package main
+
+import (
+	"bytes"
+	"os/exec"
+	"strings"
+)
+
+func candidateProviders() []Provider {
+	return []Provider{mprisProvider{}}
+}
+
+/* ---------- MPRIS (via playerctl, any MPRIS-compliant D-Bus player) ---------- */
+
+type mprisProvider struct{}
+
+func (mprisProvider) Name() string { return "mpris" }
+
+func playerctl(args ...string) (string, error) {
+	cmd := exec.Command("playerctl", args...)
+	var out bytes.Buffer
+	cmd.Stdout = &out
+	err := cmd.Run()
+	return strings.TrimSpace(out.String()), err
+}
+
+func (mprisProvider) NowPlaying() (Track, error) {
+	status, err := playerctl("status")
+	if err != nil || status != "Playing" {
+		// No MPRIS player reachable, or nothing playing; either way this
+		// isn't an error worth surfacing to the caller.
+		return Track{}, nil
+	}
+
+	title, err := playerctl("metadata", "title")
+	if err != nil {
+		return Track{}, err
+	}
+	artist, err := playerctl("metadata", "artist")
+	if err != nil {
+		return Track{}, err
+	}
+	artURL, err := playerctl("metadata", "mpris:artUrl")
+	if err != nil {
+		return Track{}, err
+	}
+
+	return Track{Title: title, Artist: artist, ArtworkURL: artURL, Playing: true}, nil
+}
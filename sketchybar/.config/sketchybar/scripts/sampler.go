@@ -0,0 +1,105 @@
+package main
+
+import (
+	"image"
+	"image/color"
+	"sync"
+)
+
+// Below this many sampled rows, goroutine setup/teardown costs more than the
+// serial loop it would replace, so sampleImage just runs inline.
+const minRowsForParallelSample = 64
+
+func pixelAt(img image.Image, x, y int) color.RGBA {
+	r16, g16, b16, _ := img.At(x, y).RGBA()
+	return color.RGBA{R: uint8(r16 >> 8), G: uint8(g16 >> 8), B: uint8(b16 >> 8), A: 255}
+}
+
+func sampleRow(img image.Image, bounds image.Rectangle, y, stride int) []color.RGBA {
+	var row []color.RGBA
+	for x := bounds.Min.X; x < bounds.Max.X; x += stride {
+		row = append(row, pixelAt(img, x, y))
+	}
+	return row
+}
+
+func sampleRowsSerial(img image.Image, bounds image.Rectangle, rows []int, stride int) []color.RGBA {
+	var out []color.RGBA
+	for _, y := range rows {
+		out = append(out, sampleRow(img, bounds, y, stride)...)
+	}
+	return out
+}
+
+// sampleRowsParallel splits rows into contiguous horizontal bands, one per
+// worker, each accumulating its own local slice before the results are
+// merged. This avoids any shared-state locking during the actual sampling.
+func sampleRowsParallel(img image.Image, bounds image.Rectangle, rows []int, stride, workers int) []color.RGBA {
+	bandSize := (len(rows) + workers - 1) / workers
+	bands := make([][]color.RGBA, workers)
+
+	var wg sync.WaitGroup
+	for w := 0; w < workers; w++ {
+		start := w * bandSize
+		if start >= len(rows) {
+			break
+		}
+		end := min(start+bandSize, len(rows))
+
+		wg.Add(1)
+		go func(w int, rowBand []int) {
+			defer wg.Done()
+			bands[w] = sampleRowsSerial(img, bounds, rowBand, stride)
+		}(w, rows[start:end])
+	}
+	wg.Wait()
+
+	var out []color.RGBA
+	for _, b := range bands {
+		out = append(out, b...)
+	}
+	return out
+}
+
+// capSamples deterministically thins pixels down to at most maxSamples by
+// picking an even stride through the slice, so downstream consumers (k-means,
+// averaging) get a bounded amount of work regardless of image size.
+func capSamples(pixels []color.RGBA, maxSamples int) []color.RGBA {
+	if maxSamples <= 0 || len(pixels) <= maxSamples {
+		return pixels
+	}
+	step := float64(len(pixels)) / float64(maxSamples)
+	out := make([]color.RGBA, maxSamples)
+	for i := range out {
+		out[i] = pixels[int(float64(i)*step)]
+	}
+	return out
+}
+
+// sampleImage is the single pixel-gathering pass shared by averageColor and
+// extractPalette: it walks img on a stride grid, using a worker pool for
+// large images, and returns at most maxSamples pixels (every sampled pixel
+// when maxSamples <= 0).
+func sampleImage(img image.Image, stride, maxSamples, workers int) []color.RGBA {
+	if stride < 1 {
+		stride = 1
+	}
+
+	bounds := img.Bounds()
+	var rows []int
+	for y := bounds.Min.Y; y < bounds.Max.Y; y += stride {
+		rows = append(rows, y)
+	}
+	if len(rows) == 0 {
+		return nil
+	}
+
+	var pixels []color.RGBA
+	if workers <= 1 || len(rows) < minRowsForParallelSample {
+		pixels = sampleRowsSerial(img, bounds, rows, stride)
+	} else {
+		pixels = sampleRowsParallel(img, bounds, rows, stride, workers)
+	}
+
+	return capSamples(pixels, maxSamples)
+}
@@ -1,50 +1,53 @@
 package main
 
 import (
-	"bytes"
+	"flag"
 	"fmt"
 	"image"
 	"image/color"
 	_ "image/jpeg"
 	_ "image/png"
 	"net/http"
-	"os/exec"
+	"os"
+	"runtime"
 	"strings"
 )
 
-func runAppleScript(script string) (string, error) {
-	cmd := exec.Command("osascript", "-e", script)
-	var out bytes.Buffer
-	cmd.Stdout = &out
-	err := cmd.Run()
-	return strings.TrimSpace(out.String()), err
-}
-
-func getArtworkURL() (string, error) {
-	return runAppleScript(`tell application "Spotify"
-		if player state is playing then
-			return artwork url of current track
-		end if
-	end tell`)
-}
-
-/* ---------- HAZY-STYLE COLOR LOGIC ---------- */
+// fetchArtwork loads artwork from either an http(s) URL (Spotify, MPRIS) or
+// a file:// URL (Apple Music, which exports artwork to a local temp file).
+func fetchArtwork(rawURL string) (image.Image, error) {
+	if path, ok := strings.CutPrefix(rawURL, "file://"); ok {
+		f, err := os.Open(path)
+		if err != nil {
+			return nil, err
+		}
+		defer f.Close()
+		img, _, err := image.Decode(f)
+		return img, err
+	}
 
-func brightness(c color.RGBA) float64 {
-	return 0.299*float64(c.R) + 0.587*float64(c.G) + 0.114*float64(c.B)
-}
+	resp, err := http.Get(rawURL)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
 
-func isTooDark(c color.RGBA) bool {
-	return brightness(c) < 100
+	img, _, err := image.Decode(resp.Body)
+	return img, err
 }
 
-func isTooCloseToWhite(c color.RGBA) bool {
-	return c.R > 200 && c.G > 200 && c.B > 200
+// cleanupLocalArtwork removes a provider-exported temp file (file:// URLs
+// only; http(s) artwork isn't ours to delete) once we're done with it,
+// whether that was via fetchArtwork or skipped entirely on a cache hit.
+// Without this, Apple Music's per-track export (providers_darwin.go) would
+// leave one jpg behind forever for every distinct track ever played.
+func cleanupLocalArtwork(rawURL string) {
+	if path, ok := strings.CutPrefix(rawURL, "file://"); ok {
+		_ = os.Remove(path)
+	}
 }
 
-func isUsable(c color.RGBA) bool {
-	return !isTooDark(c) && !isTooCloseToWhite(c)
-}
+/* ---------- HAZY-STYLE COLOR LOGIC ---------- */
 
 func darken(c color.RGBA, factor float64) color.RGBA {
 	return color.RGBA{
@@ -55,82 +58,20 @@ func darken(c color.RGBA, factor float64) color.RGBA {
 	}
 }
 
-func readableTextColor(bg color.RGBA) color.RGBA {
-	if brightness(bg) > 140 {
-		return color.RGBA{R: 20, G: 20, B: 20, A: 255}
-	}
-	return color.RGBA{R: 240, G: 240, B: 240, A: 255}
-}
-
-/*
-DOMINANT COLOR
-Histogram-based, Hazy-style filtering + fallback
-*/
-func dominantColor(img image.Image) color.RGBA {
-	bounds := img.Bounds()
-	hist := make(map[color.RGBA]int)
-
-	// Pass 1: filtered
-	for y := bounds.Min.Y; y < bounds.Max.Y; y += 5 {
-		for x := bounds.Min.X; x < bounds.Max.X; x += 5 {
-			r16, g16, b16, _ := img.At(x, y).RGBA()
-			c := color.RGBA{
-				R: uint8(r16 >> 8),
-				G: uint8(g16 >> 8),
-				B: uint8(b16 >> 8),
-				A: 255,
-			}
-			if !isUsable(c) {
-				continue
-			}
-			hist[c]++
-		}
-	}
-
-	// Fallback: no filtering (Hazy retry logic)
-	if len(hist) == 0 {
-		for y := bounds.Min.Y; y < bounds.Max.Y; y += 5 {
-			for x := bounds.Min.X; x < bounds.Max.X; x += 5 {
-				r16, g16, b16, _ := img.At(x, y).RGBA()
-				c := color.RGBA{
-					R: uint8(r16 >> 8),
-					G: uint8(g16 >> 8),
-					B: uint8(b16 >> 8),
-					A: 255,
-				}
-				hist[c]++
-			}
-		}
-	}
-
-	var max int
-	var dominant color.RGBA
-	for c, count := range hist {
-		if count > max {
-			max = count
-			dominant = c
-		}
-	}
-
-	return dominant
-}
-
 /*
 BACKGROUND COLOR
 Average + darkened for UI stability
 */
-func averageColor(img image.Image) color.RGBA {
-	bounds := img.Bounds()
+func averageColor(pixels []color.RGBA) color.RGBA {
 	var rSum, gSum, bSum, count uint64
-
-	for y := bounds.Min.Y; y < bounds.Max.Y; y += 8 {
-		for x := bounds.Min.X; x < bounds.Max.X; x += 8 {
-			r16, g16, b16, _ := img.At(x, y).RGBA()
-			rSum += uint64(r16 >> 8)
-			gSum += uint64(g16 >> 8)
-			bSum += uint64(b16 >> 8)
-			count++
-		}
+	for _, p := range pixels {
+		rSum += uint64(p.R)
+		gSum += uint64(p.G)
+		bSum += uint64(p.B)
+		count++
+	}
+	if count == 0 {
+		return color.RGBA{A: 255}
 	}
 
 	return color.RGBA{
@@ -141,33 +82,82 @@ func averageColor(img image.Image) color.RGBA {
 	}
 }
 
+const (
+	// defaultPaletteSize is how many clusters extractPalette is asked for;
+	// the ranked result gives us DOMINANT, ACCENT, and MUTED in one pass.
+	defaultPaletteSize  = 5
+	defaultSampleStride = 6
+	defaultMaxSamples   = 4096
+)
+
+// colorResult is the full set of colors this tool can emit for one piece of
+// artwork. It's also the unit the on-disk cache stores, so a cache hit can
+// skip the fetch/decode/palette-extraction path entirely.
+type colorResult struct {
+	Background color.RGBA
+	Label      color.RGBA
+	Icon       color.RGBA
+	Dominant   color.RGBA
+	HasAccent  bool
+	Accent     color.RGBA
+	HasMuted   bool
+	Muted      color.RGBA
+}
+
 func main() {
-	url, err := getArtworkURL()
-	if err != nil || url == "" {
+	providerFlag := flag.String("provider", "auto", "now-playing source: auto, spotify, music, or mpris")
+	cacheSizeFlag := flag.Int("cache-size", defaultCacheSize, "max number of palette results to keep in the on-disk cache")
+	formatFlag := flag.String("format", string(formatSketchybar), "output format: sketchybar, waybar, json, or shell")
+	emitFlag := flag.String("emit", defaultEmitFlag(), "comma-separated fields to emit: background,label,icon,dominant,accent,muted")
+	strideFlag := flag.Int("stride", defaultSampleStride, "pixel sampling stride (both axes)")
+	maxSamplesFlag := flag.Int("max-samples", defaultMaxSamples, "max pixels fed into averaging/clustering")
+	workersFlag := flag.Int("workers", runtime.NumCPU(), "worker goroutines for pixel sampling")
+	paletteSizeFlag := flag.Int("palette-size", defaultPaletteSize, "number of k-means clusters (k) to extract from the artwork")
+	flag.Parse()
+
+	emit := parseEmitFields(*emitFlag)
+
+	_, track, err := selectProvider(*providerFlag)
+	if err != nil || !track.Playing || track.ArtworkURL == "" {
 		return
 	}
+	defer cleanupLocalArtwork(track.ArtworkURL)
+
+	var result colorResult
+	if cached, ok := loadCachedResult(track.ArtworkURL); ok {
+		result = cached
+	} else {
+		img, err := fetchArtwork(track.ArtworkURL)
+		if err != nil {
+			return
+		}
 
-	resp, err := http.Get(url)
-	if err != nil {
-		return
-	}
-	defer resp.Body.Close()
+		pixels := sampleImage(img, *strideFlag, *maxSamplesFlag, *workersFlag)
+		palette := extractPalette(pixels, *paletteSizeFlag)
+		bg := averageColor(pixels)
 
-	img, _, err := image.Decode(resp.Body)
-	if err != nil {
-		return
-	}
+		// Tone down background (VERY important for SketchyBar)
+		bg = darken(bg, 0.75)
 
-	dominant := dominantColor(img)
-	bg := averageColor(img)
+		text, bg := readableTextColor(bg)
 
-	// Tone down background (VERY important for SketchyBar)
-	bg = darken(bg, 0.75)
+		result = colorResult{Background: bg, Label: text, Icon: text}
+		if len(palette) > 0 {
+			result.Dominant = palette[0]
+		}
+		if len(palette) > 1 {
+			result.HasAccent, result.Accent = true, palette[1]
+		}
+		if len(palette) > 2 {
+			result.HasMuted, result.Muted = true, palette[2]
+		}
 
-	text := readableTextColor(bg)
+		storeCachedResult(track.ArtworkURL, result, *cacheSizeFlag)
+	}
 
-	fmt.Printf("BACKGROUND=0xFF%02X%02X%02X\n", bg.R, bg.G, bg.B)
-	fmt.Printf("LABEL=0xFF%02X%02X%02X\n", text.R, text.G, text.B)
-	fmt.Printf("ICON=0xFF%02X%02X%02X\n", text.R, text.G, text.B)
-	fmt.Printf("DOMINANT=0xFF%02X%02X%02X\n", dominant.R, dominant.G, dominant.B)
+	out, err := renderResult(outputFormat(*formatFlag), result, track, emit)
+	if err != nil {
+		return
+	}
+	fmt.Print(out)
 }